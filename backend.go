@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+// ObjectInfo describes the result of a successful PutObject call, normalized
+// across backends that each return slightly different metadata.
+type ObjectInfo struct {
+	Key  string
+	Size int64
+	ETag string
+}
+
+// PutOptions carries per-object metadata and transfer tuning that a backend
+// may use when writing the object.
+type PutOptions struct {
+	ContentType string
+	// Concurrency, when non-zero, sets the number of parts the S3 backend
+	// uploads in parallel for a multipart upload. minio-go v6 picks its own
+	// part size internally; there's no knob to override it.
+	Concurrency uint
+}
+
+// StorageBackend is the destination-agnostic interface that copyWorker writes
+// through. Each supported destination (S3, GCS, Azure Blob, local filesystem)
+// implements this against its own SDK/client so the worker loop never has to
+// know which one it's talking to.
+type StorageBackend interface {
+	// Name identifies the backend for logging (e.g. "s3", "gcs", "azure", "local").
+	Name() string
+	// PutObject copies the file at localPath to objectPath on the backend.
+	PutObject(ctx context.Context, objectPath string, localPath string, opts PutOptions) (ObjectInfo, error)
+	// StatObject returns the current metadata (including ETag) for an
+	// already-uploaded object, used by --verify to confirm a prior upload.
+	StatObject(ctx context.Context, objectPath string) (ObjectInfo, error)
+	// Close releases any resources (connections, file handles) held by the backend.
+	Close() error
+}
+
+// newBackend constructs the StorageBackend selected by the "backend" config
+// value, using the backend-specific sub-section of config for its settings.
+// workerID is used by backends (currently only s3) that round-robin across
+// multiple endpoints.
+func newBackend(kind string, workerID int) (StorageBackend, error) {
+	switch kind {
+	case "", "s3":
+		return newS3Backend(workerID)
+	case "gcs":
+		return newGCSBackend()
+	case "azure":
+		return newAzureBackend()
+	case "local":
+		return newLocalBackend()
+	default:
+		return nil, fmt.Errorf("unknown backend %q", kind)
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+
+	"github.com/Azure/azure-storage-blob-go/azblob"
+	"github.com/spf13/viper"
+)
+
+// azureConfig holds the settings loaded from the "azure" config sub-section.
+type azureConfig struct {
+	AccountName string `mapstructure:"accountName"`
+	AccountKey  string `mapstructure:"accountKey"`
+	Container   string `mapstructure:"container"`
+}
+
+// AzureBackend writes objects to an Azure Blob Storage container.
+type AzureBackend struct {
+	containerURL azblob.ContainerURL
+}
+
+// azureEndpoint is the pseudo-endpoint name AzureBackend reports to
+// withRetry's shared circuit breaker, since Azure (unlike S3) has no
+// endpoint list to round-robin over.
+const azureEndpoint = "azure"
+
+func newAzureBackend() (StorageBackend, error) {
+	cfg := azureConfig{}
+	if err := viper.UnmarshalKey("azure", &cfg); err != nil {
+		return nil, err
+	}
+
+	credential, err := azblob.NewSharedKeyCredential(cfg.AccountName, cfg.AccountKey)
+	if err != nil {
+		return nil, err
+	}
+	pipeline := azblob.NewPipeline(credential, azblob.PipelineOptions{})
+	containerU, err := url.Parse(fmt.Sprintf(
+		"https://%s.blob.core.windows.net/%s", cfg.AccountName, cfg.Container,
+	))
+	if err != nil {
+		return nil, err
+	}
+	containerURL := azblob.NewContainerURL(*containerU, pipeline)
+	return &AzureBackend{containerURL: containerURL}, nil
+}
+
+func (b *AzureBackend) Name() string {
+	return "azure"
+}
+
+func (b *AzureBackend) PutObject(ctx context.Context, objectPath string, localPath string, opts PutOptions) (ObjectInfo, error) {
+	src, err := os.Open(localPath)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+
+	blobURL := b.containerURL.NewBlockBlobURL(objectPath)
+	var etag string
+	err = withRetry(ctx, retryCfg, healthBreaker, azureEndpoint, func() error {
+		if _, seekErr := src.Seek(0, io.SeekStart); seekErr != nil {
+			return seekErr
+		}
+		resp, uploadErr := azblob.UploadFileToBlockBlob(ctx, src, blobURL, azblob.UploadToBlockBlobOptions{
+			BlobHTTPHeaders: azblob.BlobHTTPHeaders{ContentType: opts.ContentType},
+		})
+		if uploadErr != nil {
+			return uploadErr
+		}
+		etag = string(resp.ETag())
+		return nil
+	})
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	return ObjectInfo{Key: objectPath, Size: info.Size(), ETag: etag}, nil
+}
+
+func (b *AzureBackend) StatObject(ctx context.Context, objectPath string) (ObjectInfo, error) {
+	blobURL := b.containerURL.NewBlockBlobURL(objectPath)
+	var info ObjectInfo
+	err := withRetry(ctx, retryCfg, healthBreaker, azureEndpoint, func() error {
+		props, statErr := blobURL.GetProperties(ctx, azblob.BlobAccessConditions{}, azblob.ClientProvidedKeyOptions{})
+		if statErr != nil {
+			return statErr
+		}
+		info = ObjectInfo{Key: objectPath, Size: props.ContentLength(), ETag: string(props.ETag())}
+		return nil
+	})
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	return info, nil
+}
+
+func (b *AzureBackend) Close() error {
+	return nil
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"cloud.google.com/go/storage"
+	"github.com/spf13/viper"
+	"google.golang.org/api/option"
+)
+
+// gcsConfig holds the settings loaded from the "gcs" config sub-section.
+type gcsConfig struct {
+	Bucket          string `mapstructure:"bucket"`
+	CredentialsFile string `mapstructure:"credentialsFile"`
+}
+
+// GCSBackend writes objects to a Google Cloud Storage bucket.
+type GCSBackend struct {
+	client *storage.Client
+	bucket string
+}
+
+// gcsEndpoint is the pseudo-endpoint name GCSBackend reports to withRetry's
+// shared circuit breaker, since GCS (unlike S3) has no endpoint list to
+// round-robin over.
+const gcsEndpoint = "gcs"
+
+func newGCSBackend() (StorageBackend, error) {
+	cfg := gcsConfig{}
+	if err := viper.UnmarshalKey("gcs", &cfg); err != nil {
+		return nil, err
+	}
+
+	var opts []option.ClientOption
+	if cfg.CredentialsFile != "" {
+		opts = append(opts, option.WithCredentialsFile(cfg.CredentialsFile))
+	}
+	client, err := storage.NewClient(context.Background(), opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &GCSBackend{client: client, bucket: cfg.Bucket}, nil
+}
+
+func (b *GCSBackend) Name() string {
+	return "gcs"
+}
+
+func (b *GCSBackend) PutObject(ctx context.Context, objectPath string, localPath string, opts PutOptions) (ObjectInfo, error) {
+	src, err := os.Open(localPath)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	defer src.Close()
+
+	var info ObjectInfo
+	err = withRetry(ctx, retryCfg, healthBreaker, gcsEndpoint, func() error {
+		if _, seekErr := src.Seek(0, io.SeekStart); seekErr != nil {
+			return seekErr
+		}
+		obj := b.client.Bucket(b.bucket).Object(objectPath)
+		w := obj.NewWriter(ctx)
+		w.ContentType = opts.ContentType
+
+		size, copyErr := io.Copy(w, src)
+		if copyErr != nil {
+			w.Close()
+			return copyErr
+		}
+		if closeErr := w.Close(); closeErr != nil {
+			return closeErr
+		}
+		info = ObjectInfo{Key: objectPath, Size: size, ETag: w.Attrs().Etag}
+		return nil
+	})
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	return info, nil
+}
+
+func (b *GCSBackend) StatObject(ctx context.Context, objectPath string) (ObjectInfo, error) {
+	var info ObjectInfo
+	err := withRetry(ctx, retryCfg, healthBreaker, gcsEndpoint, func() error {
+		attrs, statErr := b.client.Bucket(b.bucket).Object(objectPath).Attrs(ctx)
+		if statErr != nil {
+			return statErr
+		}
+		info = ObjectInfo{Key: objectPath, Size: attrs.Size, ETag: attrs.Etag}
+		return nil
+	})
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	return info, nil
+}
+
+func (b *GCSBackend) Close() error {
+	return b.client.Close()
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/viper"
+)
+
+// localConfig holds the settings loaded from the "local" config sub-section.
+type localConfig struct {
+	DestDir string `mapstructure:"destDir"`
+}
+
+// LocalBackend copies files into a directory on the local filesystem. It
+// exists for dry-runs and staging: the "bucket" is just a directory tree
+// mirroring the object path.
+type LocalBackend struct {
+	destDir string
+}
+
+func newLocalBackend() (StorageBackend, error) {
+	cfg := localConfig{}
+	if err := viper.UnmarshalKey("local", &cfg); err != nil {
+		return nil, err
+	}
+	if cfg.DestDir == "" {
+		cfg.DestDir = "."
+	}
+	return &LocalBackend{destDir: cfg.DestDir}, nil
+}
+
+func (b *LocalBackend) Name() string {
+	return "local"
+}
+
+func (b *LocalBackend) PutObject(ctx context.Context, objectPath string, localPath string, opts PutOptions) (ObjectInfo, error) {
+	dstPath := filepath.Join(b.destDir, objectPath)
+	if err := os.MkdirAll(filepath.Dir(dstPath), 0755); err != nil {
+		return ObjectInfo{}, err
+	}
+
+	src, err := os.Open(localPath)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	defer src.Close()
+
+	dst, err := os.Create(dstPath)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	defer dst.Close()
+
+	size, err := io.Copy(dst, src)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	return ObjectInfo{Key: objectPath, Size: size}, nil
+}
+
+func (b *LocalBackend) StatObject(ctx context.Context, objectPath string) (ObjectInfo, error) {
+	info, err := os.Stat(filepath.Join(b.destDir, objectPath))
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	return ObjectInfo{Key: objectPath, Size: info.Size()}, nil
+}
+
+func (b *LocalBackend) Close() error {
+	return nil
+}
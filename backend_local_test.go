@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocalBackendPutObjectAndStatObject(t *testing.T) {
+	srcDir := t.TempDir()
+	srcPath := filepath.Join(srcDir, "src.txt")
+	content := []byte("hello world")
+	if err := os.WriteFile(srcPath, content, 0644); err != nil {
+		t.Fatalf("WriteFile() returned error: %v", err)
+	}
+
+	backend := &LocalBackend{destDir: t.TempDir()}
+	ctx := context.Background()
+
+	info, err := backend.PutObject(ctx, "a/b/c.txt", srcPath, PutOptions{})
+	if err != nil {
+		t.Fatalf("PutObject() returned error: %v", err)
+	}
+	if info.Size != int64(len(content)) {
+		t.Errorf("PutObject() Size = %d, want %d", info.Size, len(content))
+	}
+
+	got, err := os.ReadFile(filepath.Join(backend.destDir, "a/b/c.txt"))
+	if err != nil {
+		t.Fatalf("ReadFile() on the copied object returned error: %v", err)
+	}
+	if string(got) != string(content) {
+		t.Errorf("copied object content = %q, want %q", got, content)
+	}
+
+	statInfo, err := backend.StatObject(ctx, "a/b/c.txt")
+	if err != nil {
+		t.Fatalf("StatObject() returned error: %v", err)
+	}
+	if statInfo.Size != int64(len(content)) {
+		t.Errorf("StatObject() Size = %d, want %d", statInfo.Size, len(content))
+	}
+}
+
+func TestLocalBackendStatObjectMissing(t *testing.T) {
+	backend := &LocalBackend{destDir: t.TempDir()}
+	if _, err := backend.StatObject(context.Background(), "does/not/exist.txt"); err == nil {
+		t.Fatal("StatObject() for a missing object returned nil error, want one")
+	}
+}
+
+func TestLocalBackendDefaultsDestDir(t *testing.T) {
+	backend, err := newLocalBackend()
+	if err != nil {
+		t.Fatalf("newLocalBackend() returned error: %v", err)
+	}
+	local, ok := backend.(*LocalBackend)
+	if !ok {
+		t.Fatalf("newLocalBackend() returned %T, want *LocalBackend", backend)
+	}
+	if local.destDir != "." {
+		t.Errorf("newLocalBackend() destDir = %q, want %q", local.destDir, ".")
+	}
+}
+
+func TestLocalBackendName(t *testing.T) {
+	backend := &LocalBackend{}
+	if backend.Name() != "local" {
+		t.Errorf("Name() = %q, want %q", backend.Name(), "local")
+	}
+}
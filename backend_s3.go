@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+
+	"github.com/minio/minio-go"
+	"github.com/minio/minio-go/pkg/credentials"
+	"github.com/spf13/viper"
+)
+
+// s3Config holds the settings loaded from the "s3" config sub-section.
+type s3Config struct {
+	Endpoints       []string `mapstructure:"endpoints"`
+	AccessKeyID     string   `mapstructure:"accessKeyID"`
+	SecretAccessKey string   `mapstructure:"secretAccessKey"`
+	Region          string   `mapstructure:"region"`
+	Bucket          string   `mapstructure:"bucket"`
+	SSL             bool     `mapstructure:"ssl"`
+}
+
+// S3Backend writes objects to an S3-compatible endpoint using minio-go. It
+// holds a client for every configured endpoint, not just the worker's
+// preferred one, so a tripped circuit breaker can redistribute dispatch to
+// a healthy endpoint instead of failing outright.
+type S3Backend struct {
+	clients   map[string]*minio.Client
+	endpoints []string
+	preferred int
+	bucket    string
+	prefix    string
+}
+
+// ObjectPrefix returns the in-bucket object prefix for this worker's
+// destination, set from the --dest URL it was built from (see
+// PrefixedBackend).
+func (b *S3Backend) ObjectPrefix() string {
+	return b.prefix
+}
+
+func newS3ClientSet(endpoints []string, accessKeyID, secretAccessKey, region string, ssl bool) (map[string]*minio.Client, error) {
+	clients := make(map[string]*minio.Client, len(endpoints))
+	for _, endpoint := range endpoints {
+		client, err := minio.NewWithOptions(endpoint, &minio.Options{
+			Creds:  credentials.NewStaticV4(accessKeyID, secretAccessKey, ""),
+			Secure: ssl,
+			Region: region,
+		})
+		if err != nil {
+			return nil, err
+		}
+		clients[endpoint] = client
+	}
+	return clients, nil
+}
+
+// newS3Backend builds an S3Backend from the "s3" config sub-section (or a
+// --dest URL, which takes priority), round-robining across endpoints by
+// workerID the same way the original flat endpoints list did.
+func newS3Backend(workerID int) (StorageBackend, error) {
+	// A --dest URL takes priority; multiple --dest values round-robin
+	// workers across distinct endpoint/bucket combinations.
+	if len(destURLs) > 0 {
+		d := destURLs[workerID%len(destURLs)]
+		clients, err := newS3ClientSet(d.Endpoints, d.AccessKeyID, d.SecretAccessKey, d.Region, d.SSL)
+		if err != nil {
+			return nil, err
+		}
+		return &S3Backend{
+			clients:   clients,
+			endpoints: d.Endpoints,
+			preferred: workerID % len(d.Endpoints),
+			bucket:    d.Bucket,
+			prefix:    d.Prefix,
+		}, nil
+	}
+
+	cfg := s3Config{}
+	if err := viper.UnmarshalKey("s3", &cfg); err != nil {
+		return nil, err
+	}
+	// Fall back to the legacy flat keys when no "s3" sub-section is set,
+	// so existing configs keep working.
+	if len(cfg.Endpoints) == 0 {
+		cfg.Endpoints = endpoints
+		cfg.AccessKeyID = accessKeyID
+		cfg.SecretAccessKey = secretAccessKey
+		cfg.Bucket = bucket
+		cfg.SSL = ssl
+	}
+	clients, err := newS3ClientSet(cfg.Endpoints, cfg.AccessKeyID, cfg.SecretAccessKey, cfg.Region, cfg.SSL)
+	if err != nil {
+		return nil, err
+	}
+	return &S3Backend{
+		clients:   clients,
+		endpoints: cfg.Endpoints,
+		preferred: workerID % len(cfg.Endpoints),
+		bucket:    cfg.Bucket,
+	}, nil
+}
+
+func (b *S3Backend) Name() string {
+	return "s3"
+}
+
+func (b *S3Backend) PutObject(ctx context.Context, objectPath string, localPath string, opts PutOptions) (ObjectInfo, error) {
+	return b.putObjectToBucket(ctx, b.bucket, objectPath, localPath, opts)
+}
+
+// PutObjectToBucket uploads to an explicit bucket rather than b.bucket,
+// letting copyWorker redirect scan-rejected files to a quarantine bucket
+// using the same client pool and retry/circuit-breaker behavior.
+func (b *S3Backend) PutObjectToBucket(ctx context.Context, bucket string, objectPath string, localPath string, opts PutOptions) (ObjectInfo, error) {
+	return b.putObjectToBucket(ctx, bucket, objectPath, localPath, opts)
+}
+
+// putObjectToBucket is the shared implementation behind PutObject and
+// PutObjectToBucket. minio-go v6's FPutObject doesn't return the resulting
+// ETag, so a successful upload is followed by a StatObject call to recover
+// it for the manifest/--verify path.
+func (b *S3Backend) putObjectToBucket(ctx context.Context, bucket string, objectPath string, localPath string, opts PutOptions) (ObjectInfo, error) {
+	endpoint := selectEndpoint(healthBreaker, b.endpoints, b.preferred)
+	client := b.clients[endpoint]
+
+	err := withRetry(ctx, retryCfg, healthBreaker, endpoint, func() error {
+		_, putErr := client.FPutObjectWithContext(ctx, bucket, objectPath, localPath, minio.PutObjectOptions{
+			ContentType: opts.ContentType,
+			NumThreads:  opts.Concurrency,
+		})
+		return putErr
+	})
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+
+	var info minio.ObjectInfo
+	err = withRetry(ctx, retryCfg, healthBreaker, endpoint, func() error {
+		var statErr error
+		info, statErr = client.StatObject(bucket, objectPath, minio.StatObjectOptions{})
+		return statErr
+	})
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	return ObjectInfo{Key: objectPath, Size: info.Size, ETag: info.ETag}, nil
+}
+
+func (b *S3Backend) StatObject(ctx context.Context, objectPath string) (ObjectInfo, error) {
+	endpoint := selectEndpoint(healthBreaker, b.endpoints, b.preferred)
+	client := b.clients[endpoint]
+
+	var info minio.ObjectInfo
+	err := withRetry(ctx, retryCfg, healthBreaker, endpoint, func() error {
+		var statErr error
+		info, statErr = client.StatObject(b.bucket, objectPath, minio.StatObjectOptions{})
+		return statErr
+	})
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	return ObjectInfo{Key: objectPath, Size: info.Size, ETag: info.ETag}, nil
+}
+
+func (b *S3Backend) Close() error {
+	return nil
+}
@@ -8,6 +8,9 @@ package main
 // The config parameter rootDir is a prefix that is added to the path of
 // the files in the filelist. This rootDir file prefix is not passed to the bucket,
 // the files are copied into the root of the bucket.
+// The destination is pluggable via the "backend" config value (s3, gcs,
+// azure, or local); see backend.go for the StorageBackend interface that
+// copyWorker is written against.
 //
 // sychan@lbl.gov 8/2019
 //
@@ -15,15 +18,14 @@ import (
 	"bufio"
 	"fmt"
 	"context"
-	"github.com/minio/minio-go"
-	"github.com/minio/minio-go/pkg/credentials"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
-	"log"
+	"net/http"
 	"os"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 )
@@ -43,66 +45,237 @@ var configPath = []string{"$HOME", "."}
 var confDefaults = map[string]string{
 	"rootDir":       "./",
 	"maxWorkers":    "1",
+	"backend":       "s3",
+	"keyMode":       "first-N-segments=4",
 	"timerInterval": "3.0",
 }
 
 // Configuration settings - globally scoped, not a big deal in this situation
 var rootDir string
 var maxWorkers int
+var backendType string
 var endpoints []string
 var accessKeyID string
 var secretAccessKey string
 var bucket string
+var destURLs []destConfig
+var keyTemplate string
+var keyMode string
+var stateDir string
+var verifyMode bool
 var timerInterval float64
 var debug bool
 var ssl bool
 
+var logFile string
+var logFormat string
+var logLevel int
+var appLogger *Logger
+
+var retryCfg retryConfig
+var healthBreaker *circuitBreaker
+var multipartThreshold uint64
+var multipartConcurrency uint
+
+var scanChain ScanChain
+var quarantineBucket string
+var quarantinePrefix string
+
+var metricsAddr string
+
 var elapsed time.Duration
-var lineCount = 0
-var lastLineCount = 0
-var totalBytes int64
+var lastLineCount int64
 var lastTotalBytes int64
 var startTime = time.Now()
-var errorLines = 0
 
-// Worker routine that initializes a minio client with an endpoint and a destination bucket
-// and then waits on a channel for file paths that should be copied into the endpoint/bucket
-func copyWorker(bucket string, url string, accessID string, secretKey string, ssl bool, files <-chan string, nodeStats chan<- CopyResult, wg *sync.WaitGroup) {
+// PrefixedBackend is implemented by backends whose destination carries an
+// in-bucket object prefix (set via a --dest URL). copyWorker and runVerify
+// use it to apply the prefix for the specific destination a backend
+// instance was built against, rather than a single value shared by every
+// worker regardless of which --dest it round-robins to.
+type PrefixedBackend interface {
+	ObjectPrefix() string
+}
 
-	ctx := context.Background()
-	defer wg.Done()
+// objectPrefixFor returns backend's object prefix when it implements
+// PrefixedBackend, or "" otherwise.
+func objectPrefixFor(backend StorageBackend) string {
+	if pb, ok := backend.(PrefixedBackend); ok {
+		return pb.ObjectPrefix()
+	}
+	return ""
+}
 
-	minioClient, err := minio.New(url, &minio.Options{
-		Creds: credentials.NewStaticV4(accessID, secretKey, ""),
-		Secure: ssl,
-	})
+// buildObjectPath turns an input line into the in-bucket object path, via
+// keyTemplate/keyMode (see keytemplate.go). When prefix is non-empty (set
+// from the --dest URL the caller's backend was built against), it's
+// prepended to the computed key.
+func buildObjectPath(ctx KeyContext, prefix string) (string, error) {
+	key, err := renderKey(ctx)
+	if err != nil {
+		return "", err
+	}
+	if prefix != "" {
+		return prefix + "/" + key, nil
+	}
+	return key, nil
+}
+
+// remoteUnchanged reports whether rec's object still exists on backend with
+// the ETag recorded at upload time, so a resumed run only skips a file once
+// it has confirmed the remote copy wasn't deleted or overwritten out-of-band
+// since. It builds the KeyContext from rec.Hash rather than re-hashing
+// filePath, since the local unchanged() check already established the file
+// matches what produced that hash.
+func remoteUnchanged(ctx context.Context, backend StorageBackend, prefix string, filePath string, rec ManifestRecord) bool {
+	objectPath, err := buildObjectPath(computeKeyContext(filePath, rec.Hash), prefix)
+	if err != nil {
+		return false
+	}
+	info, err := backend.StatObject(ctx, objectPath)
 	if err != nil {
-		log.Fatalln(err)
+		return false
 	}
+	return info.ETag == rec.ETag
+}
+
+// Worker routine that waits on a channel for file paths that should be
+// copied into the given storage backend. The backend abstracts over the
+// actual destination (S3, GCS, Azure, local filesystem) so this loop never
+// needs to know which one it's talking to.
+func copyWorker(workerID int, backend StorageBackend, manifest *Manifest, files <-chan string, nodeStats chan<- CopyResult, wg *sync.WaitGroup) {
+
+	ctx := context.Background()
+	defer wg.Done()
+	defer backend.Close()
+	workerStarted()
+	defer workerStopped()
+
+	prefix := objectPrefixFor(backend)
 	count := 0
 	for filePath := range files {
-		stringArray := strings.Split(filePath,"/")
-		objectPath := stringArray[0] + "/" + stringArray[1] + "/" + stringArray[2] + "/" + stringArray[3]
 		fullPath := rootDir + filePath
-		uploadInfo, err := minioClient.FPutObject(ctx, bucket, objectPath, fullPath, minio.PutObjectOptions{})
+
+		if manifest != nil {
+			if info, statErr := os.Stat(fullPath); statErr == nil {
+				if rec, found, lookupErr := manifest.Lookup(filePath); lookupErr == nil && found {
+					if (rec.Status == statusUploaded || rec.Status == statusVerified) && rec.unchanged(info) && remoteUnchanged(ctx, backend, prefix, filePath, rec) {
+						nodeStats <- CopyResult{path: filePath, bytes: rec.Size, err: nil}
+						count++
+						continue
+					}
+				}
+			}
+		}
+
+		keyCtx := computeKeyContext(filePath, "")
+		objectPath, err := buildObjectPath(keyCtx, prefix)
 		if err != nil {
-			log.Printf(err.Error())
+			appLogger.Error(Fields{"worker": workerID, "path": filePath}, "key template error: %s", err)
+			nodeStats <- CopyResult{path: filePath, bytes: 0, err: err}
+			count++
+			continue
+		}
+
+		if len(scanChain) > 0 {
+			if scanErr := scanChain.Scan(ctx, filePath, fullPath); scanErr != nil {
+				appLogger.Warn(Fields{"worker": workerID, "path": filePath}, "rejected by pre-upload scan: %s", scanErr)
+				if quarantineBucket != "" {
+					if qb, ok := backend.(QuarantineCapable); ok {
+						qPath := quarantinePrefix + "/" + objectPath
+						if _, qErr := qb.PutObjectToBucket(ctx, quarantineBucket, qPath, fullPath, PutOptions{ContentType: keyCtx.MimeType}); qErr != nil {
+							appLogger.Error(Fields{"worker": workerID, "path": filePath}, "quarantine upload failed: %s", qErr)
+						}
+					} else {
+						appLogger.Error(Fields{"worker": workerID, "path": filePath}, "backend %s does not support quarantine uploads", backend.Name())
+					}
+				}
+				nodeStats <- CopyResult{path: filePath, bytes: 0, err: scanErr}
+				count++
+				continue
+			}
+		}
+
+		putOpts := PutOptions{ContentType: keyCtx.MimeType}
+		if uint64(keyCtx.Size) >= multipartThreshold {
+			putOpts.Concurrency = multipartConcurrency
+		}
+		liveStats.incInFlight()
+		objInfo, err := backend.PutObject(ctx, objectPath, fullPath, putOpts)
+		liveStats.decInFlight()
+		fields := Fields{"worker": workerID, "backend": backend.Name(), "path": objectPath, "bytes": objInfo.Size}
+		if err != nil {
+			appLogger.Error(fields, "upload failed: %s", err)
+		} else {
+			appLogger.Info(fields, "uploaded, etag=%s", objInfo.ETag)
 		}
-		log.Printf("ETag: %s VersionID: %s", uploadInfo.ETag,uploadInfo.VersionID)
+
+		if manifest != nil {
+			rec := ManifestRecord{Size: objInfo.Size, ETag: objInfo.ETag, Status: statusUploaded, Hash: keyCtx.SHA256}
+			if info, statErr := os.Stat(fullPath); statErr == nil {
+				rec.Size = info.Size()
+				rec.ModTime = info.ModTime()
+			}
+			if rec.Hash == "" {
+				if hash, hashErr := hashFile(fullPath); hashErr == nil {
+					rec.Hash = hash
+				}
+			}
+			if err != nil {
+				rec.Status = statusFailed
+			}
+			if putErr := manifest.Put(filePath, rec); putErr != nil {
+				appLogger.Error(Fields{"worker": workerID, "path": filePath}, "manifest write failed: %s", putErr)
+			}
+		}
+
 		nodeStats <- CopyResult{path: filePath, bytes: 0, err: err}
 		count++
 	}
 }
 
+// runVerify re-reads the remote ETag for every manifest entry marked
+// uploaded and promotes it to verified when the ETag still matches,
+// otherwise marks it failed so the next normal run re-uploads it.
+func runVerify(manifest *Manifest) error {
+	backend, err := newBackend(backendType, 0)
+	if err != nil {
+		return err
+	}
+	defer backend.Close()
+
+	ctx := context.Background()
+	prefix := objectPrefixFor(backend)
+	return manifest.ForEachStatus(statusUploaded, func(path string, rec ManifestRecord) error {
+		objectPath, err := buildObjectPath(computeKeyContext(path, rec.Hash), prefix)
+		if err != nil {
+			appLogger.Error(Fields{"path": path}, "key template error: %s", err)
+			rec.Status = statusFailed
+			return manifest.Put(path, rec)
+		}
+		info, err := backend.StatObject(ctx, objectPath)
+		if err != nil {
+			appLogger.Error(Fields{"path": path}, "verify failed: %s", err)
+			rec.Status = statusFailed
+		} else if info.ETag == rec.ETag {
+			rec.Status = statusVerified
+		} else {
+			rec.Status = statusFailed
+		}
+		return manifest.Put(path, rec)
+	})
+}
+
 // Worker routine that is given a file to read, and a channel to write each line to.
 // Once the input file is finished, close the channel
 func fileList(srcFilePath string, files chan<- string) {
 	file, err := os.Open(srcFilePath)
 	if err != nil {
-		log.Fatal(err)
+		appLogger.Fatal(nil, "%s", err)
 	}
 	defer file.Close()
 
+	markInputOpen(true)
 	scanner := bufio.NewScanner(file)
 	count := 0
 	for scanner.Scan() {
@@ -111,19 +284,28 @@ func fileList(srcFilePath string, files chan<- string) {
 		count++
 	}
 	close(files)
-	log.Printf("Read in %d lines", count)
+	markInputOpen(false)
+	appLogger.Info(Fields{"lines": count}, "Read in %d lines", count)
 }
 
 func printStats() {
+	lineCount := atomic.LoadInt64(&liveStats.completed)
+	totalBytes := atomic.LoadInt64(&liveStats.bytes)
+	errorLines := atomic.LoadInt64(&liveStats.failed)
+
 	elapsed = time.Since(startTime)
 	bytesPerSec := int64(float64(totalBytes) / elapsed.Seconds())
-	lastBytesPerSec := int(float64(totalBytes-lastTotalBytes) / float64(timerInterval))
+	lastBytesPerSec := int64(float64(totalBytes-lastTotalBytes) / float64(timerInterval))
 	lastTotalBytes = totalBytes
-	filesPerSec := int(float64(lineCount) / elapsed.Seconds())
-	lastFilesPerSec := int(float64(lineCount-lastLineCount) / timerInterval)
+	filesPerSec := int64(float64(lineCount) / elapsed.Seconds())
+	lastFilesPerSec := int64(float64(lineCount-lastLineCount) / timerInterval)
 	lastLineCount = lineCount
-	fmt.Printf("%6.0fs, %d files ( %d err), %d bytes, %d bytes/s, %d files/s, lastinterval: %d bytes/s %d files/s\n",
-		elapsed.Seconds(), lineCount, errorLines, totalBytes, bytesPerSec, filesPerSec, lastBytesPerSec, lastFilesPerSec)
+	appLogger.Info(Fields{
+		"filesPerSec":     filesPerSec,
+		"bytesPerSec":     bytesPerSec,
+		"lastBytesPerSec": lastBytesPerSec,
+		"lastFilesPerSec": lastFilesPerSec,
+	}, "%6.0fs, %d files ( %d err), %d bytes", elapsed.Seconds(), lineCount, errorLines, totalBytes)
 }
 
 func intervalStats(ticker <-chan time.Time) {
@@ -134,19 +316,21 @@ func intervalStats(ticker <-chan time.Time) {
 
 func accumulateResults(nodeStats <-chan CopyResult, done chan<- bool) {
 	for node := range nodeStats {
-		if debug {
-			fmt.Printf("Read stats for %s size %d\n", node.path, node.bytes)
-		}
-		lineCount++
-		totalBytes += node.bytes
+		appLogger.Debug(Fields{"path": node.path, "bytes": node.bytes}, "read stats")
 		if node.err != nil {
-			errorLines++
+			liveStats.addFailed()
+		} else {
+			liveStats.addCompleted(node.bytes)
 		}
 	}
 	done <- true
 }
 
 func readConfig() {
+	// Bootstrap logger so config errors below are reported consistently;
+	// it's rebuilt below once --log-file/--log-format are known.
+	appLogger = newLogger(os.Stdout, "text")
+
 	viper.SetConfigName(configFile)
 	viper.SetConfigType(configFileType)
 	for _, cPath := range configPath {
@@ -156,26 +340,54 @@ func readConfig() {
 	if err := viper.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
 			// Config file not found; ignore error
-			fmt.Printf("Warning: %s\n", err)
+			appLogger.Warn(nil, "%s", err)
 		} else {
-			log.Fatalln(err)
+			appLogger.Fatal(nil, "%s", err)
 		}
 	}
 	pflag.String("rootDir", confDefaults["rootDir"], "Base directory on local filesystem for objects to tbe moved")
 	m, _ := strconv.Atoi(confDefaults["maxWorkers"])
 	pflag.Int("maxWorkers", m, "Number of workers to start (must be less than # of files to copy")
+	pflag.String("backend", confDefaults["backend"], "Storage backend to write objects to: s3, gcs, azure, or local")
 	pflag.StringSlice("endpoints", strings.Split(confDefaults["endpoints"], ","), "List of ip:port S3 endpoints to write objects to")
 	pflag.String("accessKeyID", string(confDefaults["accessKeyID"]), "AccessKeyID (username) for S3 endpoints")
 	pflag.String("secretAccessKey", confDefaults["secretAccessKey"], "SecretAccessKey (password) for S3 enpoints")
 	pflag.String("bucket", confDefaults["bucket"], "Name of the bucket that all files should be written to")
+	pflag.StringArray("dest", nil, "Destination URL(s) of the form s3://ACCESS:SECRET@host:port[,host2:port]/region/bucket[/prefix], "+
+		"overriding endpoints/accessKeyID/secretAccessKey/bucket/ssl. Repeat to round-robin workers across destinations")
 	t, _ := strconv.ParseFloat(confDefaults["timerInterval"], 64)
 	pflag.Float64("timerInterval", t, "Numbers of seconds between status messages. Use zero or negative value to turn off status updates")
 	pflag.Bool("debug", false, "Output detailed information for debugging")
 	pflag.Bool("ssl", false, "Use ssl for endpoint connection")
+	pflag.String("keyTemplate", "", "Go text/template expression computing the object key from a KeyContext "+
+		"(Line, Segments, Basename, Size, ModTime, SHA256, MimeType); overrides keyMode when set")
+	pflag.String("keyMode", confDefaults["keyMode"], "Named object-key mode: full-path, basename, strip-prefix=N, "+
+		"or first-N-segments=N (first-N-segments=4 matches the original hard-coded layout)")
+	pflag.String("state-dir", "", "Directory for the resumable-upload manifest; leave empty to disable resume tracking")
+	pflag.Bool("verify", false, "Re-check remote ETags for already-uploaded files in the manifest and mark them verified, then exit")
+	pflag.String("log-file", "", "Write logs to this file instead of stdout")
+	pflag.String("log-format", "text", "Log output format: text or json")
+	pflag.String("log-level", "info", "Minimum log level to emit: debug, info, warn, error")
+	pflag.Int("max-retries", 3, "Maximum retry attempts for a retryable upload failure")
+	pflag.Duration("retry-base-delay", 200*time.Millisecond, "Base delay for exponential backoff between retries")
+	pflag.Duration("retry-max-delay", 30*time.Second, "Maximum delay for exponential backoff between retries")
+	pflag.Int("circuit-breaker-window", 20, "Number of recent requests an endpoint's error rate is computed over")
+	pflag.Float64("circuit-breaker-threshold", 0.5, "Error rate (0-1) over the window that trips an endpoint's circuit breaker")
+	pflag.Duration("circuit-breaker-cooldown", 30*time.Second, "How long a tripped endpoint is skipped before retrying it")
+	pflag.Uint64("multipart-threshold", 128*1024*1024, "File size above which uploads use concurrent multipart instead of a single stream")
+	pflag.Uint("multipart-concurrency", 4, "Number of concurrent part uploads per multipart transfer")
+	pflag.String("scan-clamd-addr", "", "Address (host:port for tcp, or a path for a unix socket) of a clamd daemon to scan files through before upload")
+	pflag.String("scan-clamd-network", "tcp", "Network for scan-clamd-addr: tcp or unix")
+	pflag.String("scan-command", "", "External command to run on each file before upload; non-zero exit rejects it")
+	pflag.String("scan-checksum-manifest", "", "Sidecar manifest of \"<sha256>  <line>\" entries; files not matching are rejected")
+	pflag.String("quarantine-bucket", "", "Bucket/container to upload scan-rejected files to instead of dropping them")
+	pflag.String("quarantine-prefix", "quarantine", "Object prefix under quarantine-bucket for rejected files")
+	pflag.String("metrics-addr", "", "Address to serve Prometheus /metrics, /healthz, and /readyz on (e.g. :9100); leave empty to disable")
 	pflag.Parse()
 	viper.BindPFlags(pflag.CommandLine)
 	rootDir = viper.GetString("rootDir")
 	maxWorkers = viper.GetInt("maxWorkers")
+	backendType = viper.GetString("backend")
 	endpoints = viper.GetStringSlice("endpoints")
 	accessKeyID = viper.GetString("accessKeyID")
 	secretAccessKey = viper.GetString("secretAccessKey")
@@ -183,20 +395,94 @@ func readConfig() {
 	timerInterval = viper.GetFloat64("timerInterval")
 	debug = viper.GetBool("debug")
 	ssl = viper.GetBool("ssl")
-	if maxWorkers < 1 {
-		log.Fatalf("maxWorkers value bad: %d", maxWorkers)
+	keyTemplate = viper.GetString("keyTemplate")
+	keyMode = viper.GetString("keyMode")
+	if err := compileKeyTemplate(); err != nil {
+		appLogger.Fatal(nil, "keyTemplate parse error: %s", err)
 	}
-	if len(endpoints) < 1 {
-		log.Fatalf("No endpoints set")
+	stateDir = viper.GetString("state-dir")
+	verifyMode = viper.GetBool("verify")
+	logFile = viper.GetString("log-file")
+	logFormat = viper.GetString("log-format")
+	switch viper.GetString("log-level") {
+	case "debug":
+		logLevel = LogDebug
+	case "warn":
+		logLevel = LogWarn
+	case "error":
+		logLevel = LogError
+	default:
+		logLevel = LogInfo
 	}
-	if len(accessKeyID) < 1 {
-		log.Fatalf("accessKeyID not set")
+	if debug && logLevel > LogDebug {
+		logLevel = LogDebug
 	}
-	if len(secretAccessKey) < 1 {
-		log.Fatalf("secretAccessKey not set")
+	logOut, err := openLogOutput(logFile)
+	if err != nil {
+		appLogger.Fatal(nil, "%s", err)
 	}
-	if len(bucket) < 1 {
-		log.Fatalf("bucket name not set")
+	appLogger = newLogger(logOut, logFormat)
+
+	retryCfg = retryConfig{
+		maxRetries: viper.GetInt("max-retries"),
+		baseDelay:  viper.GetDuration("retry-base-delay"),
+		maxDelay:   viper.GetDuration("retry-max-delay"),
+	}
+	healthBreaker = newCircuitBreaker(
+		viper.GetInt("circuit-breaker-window"),
+		viper.GetFloat64("circuit-breaker-threshold"),
+		viper.GetDuration("circuit-breaker-cooldown"),
+	)
+	multipartThreshold = viper.GetUint64("multipart-threshold")
+	multipartConcurrency = viper.GetUint("multipart-concurrency")
+
+	quarantineBucket = viper.GetString("quarantine-bucket")
+	quarantinePrefix = viper.GetString("quarantine-prefix")
+	metricsAddr = viper.GetString("metrics-addr")
+	if addr := viper.GetString("scan-clamd-addr"); addr != "" {
+		network := viper.GetString("scan-clamd-network")
+		if network == "" {
+			network = "tcp"
+		}
+		scanChain = append(scanChain, &clamdScanner{network: network, addr: addr})
+	}
+	if cmd := viper.GetString("scan-command"); cmd != "" {
+		scanChain = append(scanChain, &commandScanner{path: cmd})
+	}
+	if manifestPath := viper.GetString("scan-checksum-manifest"); manifestPath != "" {
+		checksum, err := loadChecksumManifest(manifestPath)
+		if err != nil {
+			appLogger.Fatal(nil, "%s", err)
+		}
+		scanChain = append(scanChain, checksum)
+	}
+
+	for _, raw := range viper.GetStringSlice("dest") {
+		d, err := parseDestURL(raw)
+		if err != nil {
+			appLogger.Fatal(nil, "%s", err)
+		}
+		destURLs = append(destURLs, d)
+	}
+	if maxWorkers < 1 {
+		appLogger.Fatal(nil, "maxWorkers value bad: %d", maxWorkers)
+	}
+	// Remaining settings are backend-specific and validated by each backend's
+	// constructor; the legacy flat S3 flags are only required when still
+	// using the default s3 backend without a "s3" config sub-section.
+	if (backendType == "" || backendType == "s3") && !viper.IsSet("s3") && len(destURLs) == 0 {
+		if len(endpoints) < 1 {
+			appLogger.Fatal(nil, "No endpoints set")
+		}
+		if len(accessKeyID) < 1 {
+			appLogger.Fatal(nil, "accessKeyID not set")
+		}
+		if len(secretAccessKey) < 1 {
+			appLogger.Fatal(nil, "secretAccessKey not set")
+		}
+		if len(bucket) < 1 {
+			appLogger.Fatal(nil, "bucket name not set")
+		}
 	}
 
 }
@@ -206,11 +492,33 @@ func main() {
 
 	readConfig()
 
+	manifest, err := openManifest(stateDir)
+	if err != nil {
+		appLogger.Fatal(nil, "%s", err)
+	}
+	defer manifest.Close()
+
+	if verifyMode {
+		if manifest == nil {
+			appLogger.Fatal(nil, "--verify requires --state-dir")
+		}
+		if err := runVerify(manifest); err != nil {
+			appLogger.Fatal(nil, "%s", err)
+		}
+		return
+	}
+
 	if len(pflag.Args()) < 1 {
 		fmt.Println("Missing parameter, provide file name!")
 		return
 	}
 
+	var metricsServer *http.Server
+	if metricsAddr != "" {
+		metricsServer = startMetricsServer(metricsAddr)
+		defer metricsServer.Close()
+	}
+
 	nodeStats := make(chan CopyResult, maxWorkers)
 	acDone := make(chan bool)
 	go accumulateResults(nodeStats, acDone)
@@ -220,15 +528,19 @@ func main() {
 
 	fmt.Printf("Spawning workers:")
 	for worker := 0; worker < maxWorkers; worker++ {
+		backend, err := newBackend(backendType, worker)
+		if err != nil {
+			appLogger.Fatal(nil, "%s", err)
+		}
 		wg.Add(1)
-		endpoint := endpoints[worker%len(endpoints)]
 		if debug {
-			fmt.Printf(" %d %s", worker, endpoint)
+			fmt.Printf(" %d %s", worker, backend.Name())
 		} else {
 			fmt.Printf(" %d", worker)
 		}
-		go copyWorker(bucket, endpoint, accessKeyID, secretAccessKey, ssl, files, nodeStats, &wg)
+		go copyWorker(worker, backend, manifest, files, nodeStats, &wg)
 	}
+	markWorkersStarted()
 	fmt.Printf("\n")
 
 	// Start pushing file paths into the file queue so that workers start processing
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// errCircuitOpen is returned by withRetry when an endpoint's breaker is open.
+func errCircuitOpen(endpoint string) error {
+	return fmt.Errorf("circuit breaker open for endpoint %s", endpoint)
+}
+
+// endpointHealth tracks a sliding window of recent outcomes for one
+// endpoint, plus the cooldown deadline while its circuit is open.
+type endpointHealth struct {
+	results   []bool // true = success, oldest first
+	openUntil time.Time
+}
+
+// circuitBreaker is a shared health map keyed by endpoint: once an
+// endpoint's error rate over the last windowSize requests exceeds
+// errorThreshold, dispatch to it pauses for cooldown so workers can
+// redistribute to healthier endpoints instead of piling up retries.
+type circuitBreaker struct {
+	mu             sync.Mutex
+	windowSize     int
+	errorThreshold float64
+	cooldown       time.Duration
+	health         map[string]*endpointHealth
+}
+
+func newCircuitBreaker(windowSize int, errorThreshold float64, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		windowSize:     windowSize,
+		errorThreshold: errorThreshold,
+		cooldown:       cooldown,
+		health:         make(map[string]*endpointHealth),
+	}
+}
+
+// Allow reports whether requests may currently be dispatched to endpoint.
+func (cb *circuitBreaker) Allow(endpoint string) bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	h := cb.health[endpoint]
+	if h == nil {
+		return true
+	}
+	return time.Now().After(h.openUntil)
+}
+
+func (cb *circuitBreaker) record(endpoint string, success bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	h := cb.health[endpoint]
+	if h == nil {
+		h = &endpointHealth{}
+		cb.health[endpoint] = h
+	}
+	h.results = append(h.results, success)
+	if len(h.results) > cb.windowSize {
+		h.results = h.results[len(h.results)-cb.windowSize:]
+	}
+	if len(h.results) < cb.windowSize {
+		return
+	}
+	errCount := 0
+	for _, ok := range h.results {
+		if !ok {
+			errCount++
+		}
+	}
+	if float64(errCount)/float64(len(h.results)) > cb.errorThreshold {
+		h.openUntil = time.Now().Add(cb.cooldown)
+	}
+}
+
+func (cb *circuitBreaker) RecordSuccess(endpoint string) { cb.record(endpoint, true) }
+func (cb *circuitBreaker) RecordFailure(endpoint string) { cb.record(endpoint, false) }
+
+// selectEndpoint picks the first healthy endpoint starting from preferred,
+// wrapping around the list, so a tripped breaker redistributes dispatch to
+// the other endpoints in the pool instead of failing immediately.
+func selectEndpoint(breaker *circuitBreaker, endpoints []string, preferred int) string {
+	if breaker == nil {
+		return endpoints[preferred]
+	}
+	n := len(endpoints)
+	for i := 0; i < n; i++ {
+		idx := (preferred + i) % n
+		if breaker.Allow(endpoints[idx]) {
+			return endpoints[idx]
+		}
+	}
+	return endpoints[preferred]
+}
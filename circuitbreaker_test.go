@@ -0,0 +1,105 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerTripsAfterThreshold(t *testing.T) {
+	cb := newCircuitBreaker(4, 0.5, time.Minute)
+
+	if !cb.Allow("ep1") {
+		t.Fatal("Allow() = false for an endpoint with no history, want true")
+	}
+
+	cb.RecordSuccess("ep1")
+	cb.RecordFailure("ep1")
+	cb.RecordFailure("ep1")
+	if !cb.Allow("ep1") {
+		t.Fatal("Allow() = false before the window fills, want true")
+	}
+
+	cb.RecordFailure("ep1")
+	if cb.Allow("ep1") {
+		t.Fatal("Allow() = true after error rate exceeded threshold over a full window, want false")
+	}
+}
+
+func TestCircuitBreakerRecoversAfterCooldown(t *testing.T) {
+	cb := newCircuitBreaker(2, 0.5, time.Millisecond)
+
+	cb.RecordFailure("ep1")
+	cb.RecordFailure("ep1")
+	if cb.Allow("ep1") {
+		t.Fatal("Allow() = true immediately after tripping, want false")
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	if !cb.Allow("ep1") {
+		t.Fatal("Allow() = false after cooldown elapsed, want true")
+	}
+}
+
+func TestCircuitBreakerSlidesWindow(t *testing.T) {
+	cb := newCircuitBreaker(2, 0.5, time.Minute)
+
+	cb.RecordFailure("ep1")
+	cb.RecordFailure("ep1")
+	if cb.Allow("ep1") {
+		t.Fatal("Allow() = true after two failures over a window of 2, want false")
+	}
+
+	cb = newCircuitBreaker(2, 0.5, time.Minute)
+	cb.RecordFailure("ep1")
+	cb.RecordSuccess("ep1")
+	cb.RecordSuccess("ep1")
+	if !cb.Allow("ep1") {
+		t.Fatal("Allow() = false once the failure slid out of the window, want true")
+	}
+}
+
+func TestSelectEndpointPrefersPreferredWhenHealthy(t *testing.T) {
+	cb := newCircuitBreaker(2, 0.5, time.Minute)
+	endpoints := []string{"ep0", "ep1", "ep2"}
+
+	got := selectEndpoint(cb, endpoints, 1)
+	if got != "ep1" {
+		t.Errorf("selectEndpoint() = %q, want %q", got, "ep1")
+	}
+}
+
+func TestSelectEndpointWrapsAroundTrippedEndpoint(t *testing.T) {
+	cb := newCircuitBreaker(2, 0.5, time.Minute)
+	endpoints := []string{"ep0", "ep1", "ep2"}
+
+	cb.RecordFailure("ep1")
+	cb.RecordFailure("ep1")
+
+	got := selectEndpoint(cb, endpoints, 1)
+	if got != "ep2" {
+		t.Errorf("selectEndpoint() = %q, want %q", got, "ep2")
+	}
+}
+
+func TestSelectEndpointFallsBackToPreferredWhenAllTripped(t *testing.T) {
+	cb := newCircuitBreaker(2, 0.5, time.Minute)
+	endpoints := []string{"ep0", "ep1"}
+
+	for _, ep := range endpoints {
+		cb.RecordFailure(ep)
+		cb.RecordFailure(ep)
+	}
+
+	got := selectEndpoint(cb, endpoints, 1)
+	if got != "ep1" {
+		t.Errorf("selectEndpoint() = %q, want %q", got, "ep1")
+	}
+}
+
+func TestSelectEndpointNilBreakerReturnsPreferred(t *testing.T) {
+	endpoints := []string{"ep0", "ep1", "ep2"}
+	got := selectEndpoint(nil, endpoints, 2)
+	if got != "ep2" {
+		t.Errorf("selectEndpoint() = %q, want %q", got, "ep2")
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// destConfig is one destination parsed out of a --dest URL of the form
+//   s3://ACCESS:SECRET@host1:port,host2:port/region/bucket/prefix
+// The scheme selects ssl (s3 = secure, s3+http = plain), the host component
+// may list several comma-separated endpoints, and everything in the path
+// after the bucket becomes the in-bucket object prefix.
+type destConfig struct {
+	Endpoints       []string
+	AccessKeyID     string
+	SecretAccessKey string
+	Region          string
+	Bucket          string
+	Prefix          string
+	SSL             bool
+}
+
+// parseDestURL parses a single --dest value. net/url isn't used here because
+// the host component is allowed to carry a comma-separated endpoint list,
+// which the standard URL authority grammar doesn't support.
+func parseDestURL(raw string) (destConfig, error) {
+	var d destConfig
+
+	schemeSep := strings.Index(raw, "://")
+	if schemeSep < 0 {
+		return d, fmt.Errorf("dest URL %q missing scheme", raw)
+	}
+	scheme := raw[:schemeSep]
+	switch scheme {
+	case "s3":
+		d.SSL = true
+	case "s3+http":
+		d.SSL = false
+	default:
+		return d, fmt.Errorf("dest URL %q has unsupported scheme %q", raw, scheme)
+	}
+	rest := raw[schemeSep+3:]
+
+	var hostPath string
+	if at := strings.Index(rest, "@"); at >= 0 {
+		creds := rest[:at]
+		hostPath = rest[at+1:]
+		parts := strings.SplitN(creds, ":", 2)
+		d.AccessKeyID = parts[0]
+		if len(parts) == 2 {
+			d.SecretAccessKey = parts[1]
+		}
+	} else {
+		hostPath = rest
+	}
+
+	slash := strings.Index(hostPath, "/")
+	if slash < 0 {
+		return d, fmt.Errorf("dest URL %q missing /region/bucket path", raw)
+	}
+	hostPart := hostPath[:slash]
+	pathPart := strings.Trim(hostPath[slash+1:], "/")
+	d.Endpoints = strings.Split(hostPart, ",")
+
+	segments := strings.SplitN(pathPart, "/", 3)
+	if len(segments) < 2 {
+		return d, fmt.Errorf("dest URL %q must include both region and bucket", raw)
+	}
+	d.Region = segments[0]
+	d.Bucket = segments[1]
+	if len(segments) == 3 {
+		d.Prefix = segments[2]
+	}
+
+	return d, nil
+}
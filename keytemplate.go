@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// KeyContext is the data made available to a --key-template expression, and
+// to the named --key-mode shorthands below.
+type KeyContext struct {
+	Line     string
+	Segments []string
+	Basename string
+	Size     int64
+	ModTime  time.Time
+	SHA256   string
+	MimeType string
+}
+
+// computeKeyContext builds the KeyContext for one input line, stat'ing the
+// local file for size/mtime and guessing its MIME type from its extension.
+// knownHash, when non-empty, is used as SHA256 instead of re-hashing the
+// file; callers that already have a trustworthy hash for filePath (e.g. a
+// manifest record for a file confirmed unchanged) pass it in to avoid a
+// redundant full-file read. Otherwise the sha256 is computed only when
+// keyTemplate actually references it, since hashing every file up front
+// would be wasteful for large batches.
+func computeKeyContext(filePath string, knownHash string) KeyContext {
+	segments := strings.Split(filePath, "/")
+	basename := segments[len(segments)-1]
+	ctx := KeyContext{
+		Line:     filePath,
+		Segments: segments,
+		Basename: basename,
+		MimeType: mime.TypeByExtension(filepath.Ext(basename)),
+	}
+
+	fullPath := rootDir + filePath
+	if info, err := os.Stat(fullPath); err == nil {
+		ctx.Size = info.Size()
+		ctx.ModTime = info.ModTime()
+	}
+	if knownHash != "" {
+		ctx.SHA256 = knownHash
+	} else if keyTemplate != "" && strings.Contains(keyTemplate, "SHA256") {
+		if hash, err := hashFile(fullPath); err == nil {
+			ctx.SHA256 = hash
+		}
+	}
+	return ctx
+}
+
+// parsedKeyTemplate is keyTemplate parsed once by compileKeyTemplate, rather
+// than per file, since renderKey runs on every line of a potentially
+// multi-million-line batch.
+var parsedKeyTemplate *template.Template
+
+// compileKeyTemplate parses keyTemplate, if set, caching the result in
+// parsedKeyTemplate for renderKey to reuse. Called once from readConfig.
+func compileKeyTemplate() error {
+	if keyTemplate == "" {
+		return nil
+	}
+	tmpl, err := template.New("key").Parse(keyTemplate)
+	if err != nil {
+		return err
+	}
+	parsedKeyTemplate = tmpl
+	return nil
+}
+
+// renderKey turns a KeyContext into the object key, using the compiled
+// keyTemplate when set, falling back to the named keyMode shorthand
+// otherwise.
+func renderKey(ctx KeyContext) (string, error) {
+	if parsedKeyTemplate != nil {
+		var buf bytes.Buffer
+		if err := parsedKeyTemplate.Execute(&buf, ctx); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	}
+	return applyKeyMode(keyMode, ctx)
+}
+
+// applyKeyMode implements the named --key-mode shorthands: full-path,
+// basename, strip-prefix=N (drop the first N segments), and
+// first-N-segments=N (keep only the first N segments, the tool's original
+// hard-coded Shock-node layout when N is 4).
+func applyKeyMode(mode string, ctx KeyContext) (string, error) {
+	switch {
+	case mode == "full-path":
+		return ctx.Line, nil
+	case mode == "basename":
+		return ctx.Basename, nil
+	case strings.HasPrefix(mode, "strip-prefix="):
+		n, err := strconv.Atoi(strings.TrimPrefix(mode, "strip-prefix="))
+		if err != nil {
+			return "", err
+		}
+		if n >= len(ctx.Segments) {
+			return "", nil
+		}
+		return strings.Join(ctx.Segments[n:], "/"), nil
+	case strings.HasPrefix(mode, "first-N-segments="):
+		n, err := strconv.Atoi(strings.TrimPrefix(mode, "first-N-segments="))
+		if err != nil {
+			return "", err
+		}
+		if n > len(ctx.Segments) {
+			n = len(ctx.Segments)
+		}
+		return strings.Join(ctx.Segments[:n], "/"), nil
+	default:
+		return "", fmt.Errorf("unknown keyMode %q", mode)
+	}
+}
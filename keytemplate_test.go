@@ -0,0 +1,113 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestApplyKeyMode(t *testing.T) {
+	ctx := KeyContext{
+		Line:     "a/b/c/d.txt",
+		Segments: []string{"a", "b", "c", "d.txt"},
+		Basename: "d.txt",
+	}
+
+	cases := []struct {
+		name    string
+		mode    string
+		want    string
+		wantErr bool
+	}{
+		{name: "full-path", mode: "full-path", want: "a/b/c/d.txt"},
+		{name: "basename", mode: "basename", want: "d.txt"},
+		{name: "strip-prefix", mode: "strip-prefix=2", want: "c/d.txt"},
+		{name: "strip-prefix past end", mode: "strip-prefix=10", want: ""},
+		{name: "first-N-segments", mode: "first-N-segments=2", want: "a/b"},
+		{name: "first-N-segments clamped", mode: "first-N-segments=10", want: "a/b/c/d.txt"},
+		{name: "unknown mode", mode: "bogus", wantErr: true},
+		{name: "strip-prefix bad N", mode: "strip-prefix=x", wantErr: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := applyKeyMode(c.mode, ctx)
+			if c.wantErr {
+				if err == nil {
+					t.Fatalf("applyKeyMode(%q) = %q, want error", c.mode, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("applyKeyMode(%q) returned error: %v", c.mode, err)
+			}
+			if got != c.want {
+				t.Errorf("applyKeyMode(%q) = %q, want %q", c.mode, got, c.want)
+			}
+		})
+	}
+}
+
+func TestRenderKeyWithCompiledTemplate(t *testing.T) {
+	origTemplate := keyTemplate
+	origParsed := parsedKeyTemplate
+	defer func() {
+		keyTemplate = origTemplate
+		parsedKeyTemplate = origParsed
+	}()
+
+	keyTemplate = "{{.Basename}}-{{.Size}}"
+	if err := compileKeyTemplate(); err != nil {
+		t.Fatalf("compileKeyTemplate() returned error: %v", err)
+	}
+	if parsedKeyTemplate == nil {
+		t.Fatal("compileKeyTemplate() left parsedKeyTemplate nil")
+	}
+
+	ctx := KeyContext{Basename: "d.txt", Size: 42, ModTime: time.Unix(0, 0)}
+	got, err := renderKey(ctx)
+	if err != nil {
+		t.Fatalf("renderKey() returned error: %v", err)
+	}
+	want := "d.txt-42"
+	if got != want {
+		t.Errorf("renderKey() = %q, want %q", got, want)
+	}
+}
+
+func TestRenderKeyFallsBackToKeyMode(t *testing.T) {
+	origTemplate := keyTemplate
+	origParsed := parsedKeyTemplate
+	origMode := keyMode
+	defer func() {
+		keyTemplate = origTemplate
+		parsedKeyTemplate = origParsed
+		keyMode = origMode
+	}()
+
+	keyTemplate = ""
+	parsedKeyTemplate = nil
+	keyMode = "basename"
+
+	ctx := KeyContext{Basename: "d.txt"}
+	got, err := renderKey(ctx)
+	if err != nil {
+		t.Fatalf("renderKey() returned error: %v", err)
+	}
+	if got != "d.txt" {
+		t.Errorf("renderKey() = %q, want %q", got, "d.txt")
+	}
+}
+
+func TestCompileKeyTemplateInvalid(t *testing.T) {
+	origTemplate := keyTemplate
+	origParsed := parsedKeyTemplate
+	defer func() {
+		keyTemplate = origTemplate
+		parsedKeyTemplate = origParsed
+	}()
+
+	keyTemplate = "{{.Basename"
+	if err := compileKeyTemplate(); err == nil {
+		t.Fatal("compileKeyTemplate() with malformed template returned nil error")
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Upload status values stored in the manifest for each input line.
+const (
+	statusPending  = "pending"
+	statusUploaded = "uploaded"
+	statusFailed   = "failed"
+	statusVerified = "verified"
+)
+
+var manifestBucket = []byte("files")
+
+// ManifestRecord is the persisted state for one input line, keyed by its
+// path. It lets a resumed run skip files that were already uploaded and
+// haven't changed on disk since.
+type ManifestRecord struct {
+	Size   int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+	Hash   string    `json:"hash"`
+	ETag   string    `json:"etag"`
+	Status string    `json:"status"`
+}
+
+// Manifest is a BoltDB-backed store of ManifestRecords, one file per
+// --state-dir.
+type Manifest struct {
+	db *bolt.DB
+}
+
+// openManifest opens (creating if necessary) the manifest database under
+// stateDir. A nil *Manifest is returned with no error when stateDir is
+// empty, so callers can treat "no manifest configured" as a no-op.
+func openManifest(stateDir string) (*Manifest, error) {
+	if stateDir == "" {
+		return nil, nil
+	}
+	if err := os.MkdirAll(stateDir, 0755); err != nil {
+		return nil, err
+	}
+	db, err := bolt.Open(filepath.Join(stateDir, "manifest.db"), 0644, nil)
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(manifestBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Manifest{db: db}, nil
+}
+
+func (m *Manifest) Close() error {
+	if m == nil {
+		return nil
+	}
+	return m.db.Close()
+}
+
+// Lookup returns the stored record for path, if any.
+func (m *Manifest) Lookup(path string) (ManifestRecord, bool, error) {
+	var rec ManifestRecord
+	found := false
+	err := m.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(manifestBucket).Get([]byte(path))
+		if v == nil {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(v, &rec)
+	})
+	return rec, found, err
+}
+
+// Put stores rec for path, overwriting any existing record.
+func (m *Manifest) Put(path string, rec ManifestRecord) error {
+	v, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return m.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(manifestBucket).Put([]byte(path), v)
+	})
+}
+
+// ForEachStatus calls fn for every record with the given status. The
+// matching records are collected during a single read transaction and fn is
+// called afterward, outside of it, so fn is free to call back into Put
+// without deadlocking against the open View (bbolt only allows one writer,
+// and a writer started from within a reader blocks forever once it needs to
+// grow the mmap).
+func (m *Manifest) ForEachStatus(status string, fn func(path string, rec ManifestRecord) error) error {
+	type entry struct {
+		path string
+		rec  ManifestRecord
+	}
+	var matches []entry
+	err := m.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(manifestBucket).ForEach(func(k, v []byte) error {
+			var rec ManifestRecord
+			if err := json.Unmarshal(v, &rec); err != nil {
+				return err
+			}
+			if rec.Status != status {
+				return nil
+			}
+			matches = append(matches, entry{path: string(k), rec: rec})
+			return nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+	for _, e := range matches {
+		if err := fn(e.path, e.rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unchanged reports whether the file at fullPath still matches the size and
+// mtime recorded the last time it was uploaded.
+func (rec ManifestRecord) unchanged(info os.FileInfo) bool {
+	return rec.Size == info.Size() && rec.ModTime.Equal(info.ModTime())
+}
+
+// hashFile computes the sha256 of the file at path, used to populate the
+// manifest record on upload.
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func openTestManifest(t *testing.T) *Manifest {
+	t.Helper()
+	m, err := openManifest(t.TempDir())
+	if err != nil {
+		t.Fatalf("openManifest() returned error: %v", err)
+	}
+	t.Cleanup(func() { m.Close() })
+	return m
+}
+
+func TestManifestOpenWithEmptyStateDirIsNoop(t *testing.T) {
+	m, err := openManifest("")
+	if err != nil {
+		t.Fatalf("openManifest(\"\") returned error: %v", err)
+	}
+	if m != nil {
+		t.Fatalf("openManifest(\"\") = %v, want nil", m)
+	}
+	if err := m.Close(); err != nil {
+		t.Fatalf("Close() on nil Manifest returned error: %v", err)
+	}
+}
+
+func TestManifestOpenCreatesDBFile(t *testing.T) {
+	dir := t.TempDir()
+	m, err := openManifest(dir)
+	if err != nil {
+		t.Fatalf("openManifest() returned error: %v", err)
+	}
+	defer m.Close()
+
+	if _, err := os.Stat(filepath.Join(dir, "manifest.db")); err != nil {
+		t.Fatalf("expected manifest.db to exist: %v", err)
+	}
+}
+
+func TestManifestPutAndLookup(t *testing.T) {
+	m := openTestManifest(t)
+
+	rec := ManifestRecord{Size: 42, ModTime: time.Unix(100, 0), Hash: "abc", ETag: "etag1", Status: statusUploaded}
+	if err := m.Put("a/b.txt", rec); err != nil {
+		t.Fatalf("Put() returned error: %v", err)
+	}
+
+	got, found, err := m.Lookup("a/b.txt")
+	if err != nil {
+		t.Fatalf("Lookup() returned error: %v", err)
+	}
+	if !found {
+		t.Fatal("Lookup() found = false, want true")
+	}
+	if got.Size != rec.Size || got.Hash != rec.Hash || got.ETag != rec.ETag || got.Status != rec.Status || !got.ModTime.Equal(rec.ModTime) {
+		t.Errorf("Lookup() = %+v, want %+v", got, rec)
+	}
+
+	if _, found, err := m.Lookup("missing"); err != nil || found {
+		t.Errorf("Lookup(\"missing\") = (found=%v, err=%v), want (false, nil)", found, err)
+	}
+}
+
+func TestManifestForEachStatusFiltersByStatus(t *testing.T) {
+	m := openTestManifest(t)
+
+	records := map[string]ManifestRecord{
+		"uploaded1": {Status: statusUploaded},
+		"uploaded2": {Status: statusUploaded},
+		"failed1":   {Status: statusFailed},
+		"verified1": {Status: statusVerified},
+	}
+	for path, rec := range records {
+		if err := m.Put(path, rec); err != nil {
+			t.Fatalf("Put(%q) returned error: %v", path, err)
+		}
+	}
+
+	var seen []string
+	err := m.ForEachStatus(statusUploaded, func(path string, rec ManifestRecord) error {
+		seen = append(seen, path)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ForEachStatus() returned error: %v", err)
+	}
+	if len(seen) != 2 {
+		t.Fatalf("ForEachStatus() visited %d records, want 2: %v", len(seen), seen)
+	}
+}
+
+// TestManifestForEachStatusAllowsNestedPut guards against the ForEachStatus
+// deadlock this fixed previously: fn must be free to write back into the
+// manifest (as runVerify does) without the callback running inside the
+// View transaction that produced it.
+func TestManifestForEachStatusAllowsNestedPut(t *testing.T) {
+	m := openTestManifest(t)
+
+	if err := m.Put("a", ManifestRecord{Status: statusUploaded}); err != nil {
+		t.Fatalf("Put() returned error: %v", err)
+	}
+	if err := m.Put("b", ManifestRecord{Status: statusUploaded}); err != nil {
+		t.Fatalf("Put() returned error: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- m.ForEachStatus(statusUploaded, func(path string, rec ManifestRecord) error {
+			rec.Status = statusVerified
+			return m.Put(path, rec)
+		})
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("ForEachStatus() returned error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("ForEachStatus() with a nested Put did not return within 5s, likely deadlocked")
+	}
+
+	rec, found, err := m.Lookup("a")
+	if err != nil || !found {
+		t.Fatalf("Lookup(\"a\") = (found=%v, err=%v), want (true, nil)", found, err)
+	}
+	if rec.Status != statusVerified {
+		t.Errorf("Lookup(\"a\").Status = %q, want %q", rec.Status, statusVerified)
+	}
+}
+
+func TestManifestRecordUnchanged(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "f.txt")
+	if err := os.WriteFile(path, []byte("hello"), 0644); err != nil {
+		t.Fatalf("WriteFile() returned error: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat() returned error: %v", err)
+	}
+
+	rec := ManifestRecord{Size: info.Size(), ModTime: info.ModTime()}
+	if !rec.unchanged(info) {
+		t.Error("unchanged() = false for a record matching the file's current size/mtime, want true")
+	}
+
+	rec.Size = info.Size() + 1
+	if rec.unchanged(info) {
+		t.Error("unchanged() = true for a record with a mismatched size, want false")
+	}
+}
+
+func TestHashFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "f.txt")
+	if err := os.WriteFile(path, []byte("hello"), 0644); err != nil {
+		t.Fatalf("WriteFile() returned error: %v", err)
+	}
+
+	got, err := hashFile(path)
+	if err != nil {
+		t.Fatalf("hashFile() returned error: %v", err)
+	}
+	want := "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	if got != want {
+		t.Errorf("hashFile() = %q, want %q", got, want)
+	}
+}
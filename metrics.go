@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Prometheus metrics, registered once at package init. Names are prefixed
+// bulk_s3_upload_ so they're unambiguous alongside other jobs' metrics in a
+// shared Prometheus instance.
+var (
+	metricUploadsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "bulk_s3_upload_uploads_total",
+		Help: "Total number of files dispatched for upload.",
+	})
+	metricUploadsCompleted = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "bulk_s3_upload_uploads_completed_total",
+		Help: "Total number of files uploaded successfully.",
+	})
+	metricUploadsFailed = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "bulk_s3_upload_uploads_failed_total",
+		Help: "Total number of files that failed to upload.",
+	})
+	metricBytesTransferred = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "bulk_s3_upload_bytes_transferred_total",
+		Help: "Total bytes successfully transferred.",
+	})
+	metricInFlight = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "bulk_s3_upload_in_flight",
+		Help: "Number of uploads currently in progress.",
+	})
+	metricWorkerSaturation = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "bulk_s3_upload_worker_saturation",
+		Help: "Fraction of workers (0-1) currently busy uploading.",
+	})
+	metricRetries = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "bulk_s3_upload_retries_total",
+		Help: "Total number of retry attempts across all uploads.",
+	})
+	metricEndpointSuccess = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "bulk_s3_upload_endpoint_success_total",
+		Help: "Successful requests per endpoint.",
+	}, []string{"endpoint"})
+	metricEndpointError = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "bulk_s3_upload_endpoint_error_total",
+		Help: "Failed requests per endpoint.",
+	}, []string{"endpoint"})
+	metricEndpointLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "bulk_s3_upload_endpoint_latency_seconds",
+		Help: "Request latency per endpoint.",
+	}, []string{"endpoint"})
+)
+
+// runStats is the atomic stats struct shared by accumulateResults and the
+// metrics collector, replacing the old package-level lineCount/totalBytes/
+// errorLines counters so both printStats and /metrics read consistent,
+// concurrency-safe totals.
+type runStats struct {
+	completed int64
+	failed    int64
+	bytes     int64
+	inFlight  int64
+}
+
+var liveStats runStats
+
+func (s *runStats) addCompleted(size int64) {
+	atomic.AddInt64(&s.completed, 1)
+	atomic.AddInt64(&s.bytes, size)
+	metricUploadsCompleted.Inc()
+	metricBytesTransferred.Add(float64(size))
+}
+
+func (s *runStats) addFailed() {
+	atomic.AddInt64(&s.failed, 1)
+	metricUploadsFailed.Inc()
+}
+
+func (s *runStats) incInFlight() {
+	atomic.AddInt64(&s.inFlight, 1)
+	metricInFlight.Inc()
+	metricUploadsTotal.Inc()
+	updateWorkerSaturation()
+}
+
+func (s *runStats) decInFlight() {
+	atomic.AddInt64(&s.inFlight, -1)
+	metricInFlight.Dec()
+	updateWorkerSaturation()
+}
+
+func updateWorkerSaturation() {
+	if maxWorkers <= 0 {
+		return
+	}
+	metricWorkerSaturation.Set(float64(atomic.LoadInt64(&liveStats.inFlight)) / float64(maxWorkers))
+}
+
+// Health-probe state: workersStarted flips true once main has begun
+// spawning workers; inputOpen and workersLive track whether the tool is
+// still actively able to accept and process work.
+var workersStarted int32
+var inputOpen int32
+var workersLive int32
+
+func markWorkersStarted()  { atomic.StoreInt32(&workersStarted, 1) }
+func markInputOpen(open bool) {
+	if open {
+		atomic.StoreInt32(&inputOpen, 1)
+	} else {
+		atomic.StoreInt32(&inputOpen, 0)
+	}
+}
+func workerStarted() { atomic.AddInt32(&workersLive, 1) }
+func workerStopped() { atomic.AddInt32(&workersLive, -1) }
+
+// startMetricsServer starts the opt-in HTTP server exposing /metrics,
+// /healthz, and /readyz.
+func startMetricsServer(addr string) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&workersStarted) == 1 {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&inputOpen) == 1 && atomic.LoadInt32(&workersLive) > 0 {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	srv := &http.Server{Addr: addr, Handler: mux}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			appLogger.Error(Fields{"addr": addr}, "metrics server stopped: %s", err)
+		}
+	}()
+	return srv
+}
+
+// instrumentedCall wraps a single backend request with the per-endpoint
+// success/error counters and latency histogram, and the shared retry
+// counter, on top of whatever withRetry itself does.
+func instrumentedCall(ctx context.Context, endpoint string, attempt int, fn func() error) error {
+	if attempt > 0 {
+		metricRetries.Inc()
+	}
+	start := time.Now()
+	err := fn()
+	metricEndpointLatency.WithLabelValues(endpoint).Observe(time.Since(start).Seconds())
+	if err != nil {
+		metricEndpointError.WithLabelValues(endpoint).Inc()
+	} else {
+		metricEndpointSuccess.WithLabelValues(endpoint).Inc()
+	}
+	return err
+}
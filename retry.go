@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/minio/minio-go"
+)
+
+// retryConfig controls the full-jitter exponential backoff applied around a
+// single upload attempt.
+type retryConfig struct {
+	maxRetries int
+	baseDelay  time.Duration
+	maxDelay   time.Duration
+}
+
+// isRetryableError distinguishes transient failures (5xx, timeouts,
+// connection resets) from fatal ones (403/404 on the source object), which
+// should surface immediately instead of burning through retries.
+func isRetryableError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errResp := minio.ToErrorResponse(err); errResp.Code != "" {
+		switch errResp.StatusCode {
+		case http.StatusForbidden, http.StatusNotFound:
+			return false
+		}
+		return errResp.StatusCode == 0 || errResp.StatusCode >= 500 || errResp.StatusCode == http.StatusTooManyRequests
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return netErr.Timeout()
+	}
+	return true
+}
+
+// fullJitterBackoff returns a random delay in [0, min(maxDelay, base*2^attempt)),
+// the "full jitter" strategy that avoids retry storms against a recovering endpoint.
+func fullJitterBackoff(base, max time.Duration, attempt int) time.Duration {
+	capDelay := base << uint(attempt)
+	if capDelay <= 0 || capDelay > max {
+		capDelay = max
+	}
+	if capDelay <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(capDelay)))
+}
+
+// withRetry runs fn, retrying retryable failures with full-jitter exponential
+// backoff up to cfg.maxRetries times. When breaker is non-nil, each attempt
+// consults and updates the shared per-endpoint health state.
+func withRetry(ctx context.Context, cfg retryConfig, breaker *circuitBreaker, endpoint string, fn func() error) error {
+	var err error
+	for attempt := 0; attempt <= cfg.maxRetries; attempt++ {
+		if breaker != nil && !breaker.Allow(endpoint) {
+			return errCircuitOpen(endpoint)
+		}
+		err = instrumentedCall(ctx, endpoint, attempt, fn)
+		if breaker != nil {
+			if err == nil {
+				breaker.RecordSuccess(endpoint)
+			} else {
+				breaker.RecordFailure(endpoint)
+			}
+		}
+		if err == nil || !isRetryableError(err) || attempt == cfg.maxRetries {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(fullJitterBackoff(cfg.baseDelay, cfg.maxDelay, attempt)):
+		}
+	}
+	return err
+}
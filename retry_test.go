@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/minio/minio-go"
+)
+
+func TestIsRetryableError(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil error", err: nil, want: false},
+		{name: "generic error", err: errors.New("boom"), want: true},
+		{
+			name: "minio 404",
+			err:  minio.ErrorResponse{Code: "NoSuchKey", StatusCode: 404},
+			want: false,
+		},
+		{
+			name: "minio 403",
+			err:  minio.ErrorResponse{Code: "AccessDenied", StatusCode: 403},
+			want: false,
+		},
+		{
+			name: "minio 500",
+			err:  minio.ErrorResponse{Code: "InternalError", StatusCode: 500},
+			want: true,
+		},
+		{
+			name: "minio 429",
+			err:  minio.ErrorResponse{Code: "TooManyRequests", StatusCode: 429},
+			want: true,
+		},
+		{
+			name: "timeout net error",
+			err:  &net.DNSError{IsTimeout: true},
+			want: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := isRetryableError(c.err); got != c.want {
+				t.Errorf("isRetryableError(%v) = %v, want %v", c.err, got, c.want)
+			}
+		})
+	}
+}
+
+func TestFullJitterBackoffBounds(t *testing.T) {
+	base := 10 * time.Millisecond
+	max := 100 * time.Millisecond
+
+	for attempt := 0; attempt < 10; attempt++ {
+		for i := 0; i < 20; i++ {
+			d := fullJitterBackoff(base, max, attempt)
+			if d < 0 || d > max {
+				t.Fatalf("fullJitterBackoff(attempt=%d) = %v, want in [0, %v]", attempt, d, max)
+			}
+		}
+	}
+}
+
+func TestFullJitterBackoffZeroBase(t *testing.T) {
+	if d := fullJitterBackoff(0, 0, 0); d != 0 {
+		t.Errorf("fullJitterBackoff(0, 0, 0) = %v, want 0", d)
+	}
+}
+
+func TestWithRetrySucceedsWithoutRetrying(t *testing.T) {
+	calls := 0
+	cfg := retryConfig{maxRetries: 3, baseDelay: time.Millisecond, maxDelay: time.Millisecond}
+	err := withRetry(context.Background(), cfg, nil, "ep1", func() error {
+		calls++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("withRetry() returned error: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("fn called %d times, want 1", calls)
+	}
+}
+
+func TestWithRetryGivesUpOnNonRetryableError(t *testing.T) {
+	calls := 0
+	cfg := retryConfig{maxRetries: 3, baseDelay: time.Millisecond, maxDelay: time.Millisecond}
+	notFound := minio.ErrorResponse{Code: "NoSuchKey", StatusCode: 404}
+	err := withRetry(context.Background(), cfg, nil, "ep1", func() error {
+		calls++
+		return notFound
+	})
+	if err != notFound {
+		t.Fatalf("withRetry() = %v, want %v", err, notFound)
+	}
+	if calls != 1 {
+		t.Errorf("fn called %d times for a non-retryable error, want 1", calls)
+	}
+}
+
+func TestWithRetryRetriesUpToMax(t *testing.T) {
+	calls := 0
+	cfg := retryConfig{maxRetries: 2, baseDelay: time.Millisecond, maxDelay: time.Millisecond}
+	boom := errors.New("boom")
+	err := withRetry(context.Background(), cfg, nil, "ep1", func() error {
+		calls++
+		return boom
+	})
+	if err != boom {
+		t.Fatalf("withRetry() = %v, want %v", err, boom)
+	}
+	if calls != cfg.maxRetries+1 {
+		t.Errorf("fn called %d times, want %d", calls, cfg.maxRetries+1)
+	}
+}
+
+func TestWithRetryRespectsOpenCircuit(t *testing.T) {
+	cb := newCircuitBreaker(1, 0.1, time.Minute)
+	cb.RecordFailure("ep1")
+
+	calls := 0
+	cfg := retryConfig{maxRetries: 3, baseDelay: time.Millisecond, maxDelay: time.Millisecond}
+	err := withRetry(context.Background(), cfg, cb, "ep1", func() error {
+		calls++
+		return nil
+	})
+	if err == nil {
+		t.Fatal("withRetry() with an open circuit returned nil error, want errCircuitOpen")
+	}
+	if calls != 0 {
+		t.Errorf("fn called %d times with an open circuit, want 0", calls)
+	}
+}
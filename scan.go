@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Scanner is one step in the pre-upload scanning pipeline. Scan returns a
+// non-nil error when localPath should be rejected; the error's text becomes
+// the rejection reason recorded in CopyResult/the logs.
+type Scanner interface {
+	Scan(ctx context.Context, line string, localPath string) error
+}
+
+// ScanChain runs each Scanner in order, stopping at the first rejection.
+type ScanChain []Scanner
+
+func (c ScanChain) Scan(ctx context.Context, line string, localPath string) error {
+	for _, s := range c {
+		if err := s.Scan(ctx, line, localPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// clamdScanner submits the file to a clamd daemon over its INSTREAM protocol
+// and rejects it if clamd reports a signature match.
+type clamdScanner struct {
+	network string // "tcp" or "unix"
+	addr    string
+}
+
+func (s *clamdScanner) Scan(ctx context.Context, line string, localPath string) error {
+	f, err := os.Open(localPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, s.network, s.addr)
+	if err != nil {
+		return fmt.Errorf("clamd: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte("zINSTREAM\000")); err != nil {
+		return fmt.Errorf("clamd: %w", err)
+	}
+	buf := make([]byte, 64*1024)
+	for {
+		n, readErr := f.Read(buf)
+		if n > 0 {
+			var size [4]byte
+			binary.BigEndian.PutUint32(size[:], uint32(n))
+			if _, err := conn.Write(size[:]); err != nil {
+				return fmt.Errorf("clamd: %w", err)
+			}
+			if _, err := conn.Write(buf[:n]); err != nil {
+				return fmt.Errorf("clamd: %w", err)
+			}
+		}
+		if readErr != nil {
+			break
+		}
+	}
+	var zero [4]byte
+	if _, err := conn.Write(zero[:]); err != nil {
+		return fmt.Errorf("clamd: %w", err)
+	}
+
+	resp, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && resp == "" {
+		return fmt.Errorf("clamd: %w", err)
+	}
+	resp = strings.TrimRight(resp, "\x00\n")
+	if strings.Contains(resp, "FOUND") {
+		return fmt.Errorf("clamd rejected %s: %s", localPath, resp)
+	}
+	return nil
+}
+
+// commandScanner runs an external command with localPath as its final
+// argument; a non-zero exit rejects the file.
+type commandScanner struct {
+	path string
+	args []string
+}
+
+func (s *commandScanner) Scan(ctx context.Context, line string, localPath string) error {
+	args := append(append([]string{}, s.args...), localPath)
+	cmd := exec.CommandContext(ctx, s.path, args...)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("scan command rejected %s: %s", localPath, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// checksumScanner requires the input line's sha256 to match a sidecar
+// manifest of "<hash>  <line>" entries, one per line (as produced by
+// sha256sum), rejecting anything unexpected or corrupted in transit.
+type checksumScanner struct {
+	expected map[string]string // line -> expected sha256
+}
+
+func loadChecksumManifest(path string) (*checksumScanner, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	expected := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		expected[fields[1]] = fields[0]
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return &checksumScanner{expected: expected}, nil
+}
+
+func (s *checksumScanner) Scan(ctx context.Context, line string, localPath string) error {
+	want, ok := s.expected[line]
+	if !ok {
+		return fmt.Errorf("checksum policy: no expected sha256 for %s", line)
+	}
+	got, err := hashFile(localPath)
+	if err != nil {
+		return err
+	}
+	if got != want {
+		return fmt.Errorf("checksum mismatch for %s: expected %s got %s", line, want, got)
+	}
+	return nil
+}
+
+// QuarantineCapable is implemented by backends that can write to a bucket
+// other than the one they were constructed with, so rejected files can be
+// routed to a quarantine bucket/prefix instead of being dropped outright.
+type QuarantineCapable interface {
+	PutObjectToBucket(ctx context.Context, bucket string, objectPath string, localPath string, opts PutOptions) (ObjectInfo, error)
+}